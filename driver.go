@@ -0,0 +1,56 @@
+package pgxtxmanager
+
+import "context"
+
+// Tx is the minimal transaction capability pgxtxmanager needs from an
+// underlying SQL driver: committing, rolling back, and nesting via
+// savepoints. Savepoint, ReleaseSavepoint and RollbackToSavepoint are only
+// invoked for nested SQLTransaction/SQLTransactionTx calls, so a driver for
+// a database without real savepoint support only needs to make those three
+// no-ops (or return an error, which callers can avoid with WithoutSavepoints).
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+	Savepoint(ctx context.Context, name string) error
+	ReleaseSavepoint(ctx context.Context, name string) error
+	RollbackToSavepoint(ctx context.Context, name string) error
+}
+
+// IsoLevel is a driver-agnostic transaction isolation level, ordered from
+// weakest to strongest. LevelDefault defers to whatever the server or
+// driver normally uses.
+type IsoLevel int
+
+const (
+	LevelDefault IsoLevel = iota
+	LevelReadUncommitted
+	LevelReadCommitted
+	LevelRepeatableRead
+	LevelSerializable
+)
+
+// TxOptions controls the isolation level and access mode of a transaction,
+// independent of any particular driver's own option type. Drivers translate
+// it to whatever their underlying library expects.
+type TxOptions struct {
+	Isolation IsoLevel
+	ReadOnly  bool
+}
+
+// Driver begins transactions for pgxtxmanager. Concrete adapters live in
+// subpackages: pgxdriver (pgx/v5), pgxpooldriver (pgxpool.Pool), sqlxdriver
+// (sqlx), stdsqldriver (database/sql), and memdriver (an in-memory fake for
+// tests).
+type Driver interface {
+	Begin(ctx context.Context, txOptions TxOptions) (Tx, error)
+}
+
+// isoLevelRank orders IsoLevel from weakest to strongest, for comparing a
+// nested call's requested isolation against the outer transaction's.
+var isoLevelRank = map[IsoLevel]int{
+	LevelDefault:         0,
+	LevelReadUncommitted: 0,
+	LevelReadCommitted:   1,
+	LevelRepeatableRead:  2,
+	LevelSerializable:    3,
+}
@@ -0,0 +1,174 @@
+package pgxtxmanager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// hookRegistry collects AfterCommit/AfterRollback callbacks for a
+// transaction. It is shared by every savepoint frame nested inside the same
+// outermost transaction. Each entry is tagged with the frame it was
+// registered under: when that frame's savepoint is released, promote
+// reassigns its entries to the parent frame so they survive; when that
+// frame's savepoint is instead rolled back, discard drops its entries
+// entirely, since the work they were meant to follow was undone. By the time
+// the outermost frame resolves, only entries still tagged with it remain,
+// and those are the only ones runAfterCommit/runAfterRollback ever run.
+type hookRegistry struct {
+	mu            sync.Mutex
+	afterCommit   []commitHook
+	afterRollback []rollbackHook
+}
+
+type commitHook struct {
+	frame *txFrame
+	fn    func() error
+}
+
+type rollbackHook struct {
+	frame *txFrame
+	fn    func()
+}
+
+func (h *hookRegistry) addAfterCommit(frame *txFrame, fn func() error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.afterCommit = append(h.afterCommit, commitHook{frame: frame, fn: fn})
+}
+
+func (h *hookRegistry) addAfterRollback(frame *txFrame, fn func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.afterRollback = append(h.afterRollback, rollbackHook{frame: frame, fn: fn})
+}
+
+// promote reassigns every hook registered under frame to outer, so they
+// survive frame's savepoint being released and become part of outer's scope.
+func (h *hookRegistry) promote(frame, outer *txFrame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := range h.afterCommit {
+		if h.afterCommit[i].frame == frame {
+			h.afterCommit[i].frame = outer
+		}
+	}
+	for i := range h.afterRollback {
+		if h.afterRollback[i].frame == frame {
+			h.afterRollback[i].frame = outer
+		}
+	}
+}
+
+// discard drops every hook registered under frame, because frame's savepoint
+// did not survive: it was rolled back, or its release itself failed.
+func (h *hookRegistry) discard(frame *txFrame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	commit := h.afterCommit[:0]
+	for _, hook := range h.afterCommit {
+		if hook.frame != frame {
+			commit = append(commit, hook)
+		}
+	}
+	h.afterCommit = commit
+
+	rollback := h.afterRollback[:0]
+	for _, hook := range h.afterRollback {
+		if hook.frame != frame {
+			rollback = append(rollback, hook)
+		}
+	}
+	h.afterRollback = rollback
+}
+
+// runAfterCommit invokes every registered AfterCommit hook in FIFO order. It
+// stops and returns at the first error, since the transaction is already
+// committed and there is nothing left to roll back.
+func (h *hookRegistry) runAfterCommit() error {
+	h.mu.Lock()
+	hooks := h.afterCommit
+	h.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook.fn(); err != nil {
+			return fmt.Errorf("pgxtxmanager: AfterCommit hook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// runAfterRollback invokes every registered AfterRollback hook in FIFO order.
+func (h *hookRegistry) runAfterRollback() {
+	h.mu.Lock()
+	hooks := h.afterRollback
+	h.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook.fn()
+	}
+}
+
+// AfterCommit registers fn to run after the outermost transaction in ctx
+// successfully commits. fn is not called if the transaction rolls back
+// instead, and it is not called at all if ctx carries no transaction. Hooks
+// run in the order they were registered; if one returns an error, the
+// remaining hooks are skipped and the error is returned from the top-level
+// SQLTransaction/SQLTransactionTx/SQLTransactionWithRetry call, but the
+// transaction itself is not rolled back since it has already committed.
+//
+// Registering from a nested SQLTransaction call scopes fn to that savepoint:
+// if the savepoint is released, fn is promoted into the enclosing
+// transaction's scope and only runs once that ultimately commits; if the
+// savepoint is rolled back instead, fn is discarded and never runs, even if
+// the outer transaction goes on to commit, since the work it was meant to
+// follow was undone.
+func AfterCommit(ctx context.Context, fn func() error) {
+	frame, ok := ctx.Value(CtxKey).(*txFrame)
+	if !ok {
+		return
+	}
+	frame.hooks.addAfterCommit(frame, fn)
+}
+
+// AfterRollback registers fn to run after the outermost transaction in ctx
+// rolls back, whether due to fn (the one passed to SQLTransaction) returning
+// an error or panicking. It does not run if the transaction commits,
+// including when commit itself or an AfterCommit hook subsequently fails,
+// since by then the transaction is no longer something pgxtxmanager can roll
+// back. It is not called at all if ctx carries no transaction.
+//
+// The same savepoint scoping as AfterCommit applies: fn registered inside a
+// nested SQLTransaction call is discarded if that savepoint rolls back,
+// since the transaction as a whole has not rolled back, merely a part of it
+// that was undone, and is promoted into the enclosing scope if the
+// savepoint is released instead.
+func AfterRollback(ctx context.Context, fn func()) {
+	frame, ok := ctx.Value(CtxKey).(*txFrame)
+	if !ok {
+		return
+	}
+	frame.hooks.addAfterRollback(frame, fn)
+}
+
+// CommitHookError reports that the outermost transaction's Tx.Commit
+// succeeded but one of its AfterCommit hooks subsequently failed. SQLTransactionWithRetry checks for it ahead of consulting
+// IsRetryable and always treats it as non-retryable, regardless of what
+// Unwrap returns, since retrying would begin a brand new transaction and
+// re-run fn even though the original transaction's work is already durable.
+type CommitHookError struct {
+	err error
+}
+
+// Error implements error.
+func (e *CommitHookError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error returned by the failing AfterCommit hook.
+func (e *CommitHookError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,145 @@
+package pgxtxmanager_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Hidayathamir/pgxtxmanager"
+	"github.com/Hidayathamir/pgxtxmanager/memdriver"
+)
+
+func TestAfterCommit_FIFOOrder(t *testing.T) {
+	driver := memdriver.New()
+	var order []int
+
+	err := pgxtxmanager.SQLTransaction(context.Background(), driver, func(ctx context.Context) error {
+		pgxtxmanager.AfterCommit(ctx, func() error {
+			order = append(order, 1)
+			return nil
+		})
+		pgxtxmanager.AfterCommit(ctx, func() error {
+			order = append(order, 2)
+			return nil
+		})
+		pgxtxmanager.AfterCommit(ctx, func() error {
+			order = append(order, 3)
+			return nil
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SQLTransaction: %v", err)
+	}
+
+	want := []int{1, 2, 3}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestAfterRollback_NotRunWhenInnerSavepointFailsButOuterCommits(t *testing.T) {
+	driver := memdriver.New()
+	rollbackRan := false
+
+	err := pgxtxmanager.SQLTransaction(context.Background(), driver, func(ctx context.Context) error {
+		pgxtxmanager.AfterRollback(ctx, func() {
+			rollbackRan = true
+		})
+
+		innerErr := pgxtxmanager.SQLTransaction(ctx, driver, func(context.Context) error {
+			return errors.New("inner failure")
+		})
+		if innerErr == nil {
+			t.Fatal("expected inner SQLTransaction to fail")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SQLTransaction: %v", err)
+	}
+
+	if rollbackRan {
+		t.Fatal("AfterRollback hook ran despite outer transaction committing")
+	}
+}
+
+func TestAfterCommit_DiscardedWhenRegisteringSavepointRollsBack(t *testing.T) {
+	driver := memdriver.New()
+	hookRan := false
+
+	err := pgxtxmanager.SQLTransaction(context.Background(), driver, func(ctx context.Context) error {
+		innerErr := pgxtxmanager.SQLTransaction(ctx, driver, func(innerCtx context.Context) error {
+			pgxtxmanager.AfterCommit(innerCtx, func() error {
+				hookRan = true
+				return nil
+			})
+			return errors.New("inner failure")
+		})
+		if innerErr == nil {
+			t.Fatal("expected inner SQLTransaction to fail")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SQLTransaction: %v", err)
+	}
+
+	if hookRan {
+		t.Fatal("AfterCommit hook registered inside the failed inner savepoint ran despite that savepoint rolling back")
+	}
+}
+
+func TestAfterCommit_PromotedWhenRegisteringSavepointCommits(t *testing.T) {
+	driver := memdriver.New()
+	hookRan := false
+
+	err := pgxtxmanager.SQLTransaction(context.Background(), driver, func(ctx context.Context) error {
+		return pgxtxmanager.SQLTransaction(ctx, driver, func(innerCtx context.Context) error {
+			pgxtxmanager.AfterCommit(innerCtx, func() error {
+				hookRan = true
+				return nil
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("SQLTransaction: %v", err)
+	}
+
+	if !hookRan {
+		t.Fatal("AfterCommit hook registered inside a savepoint that released should fire once the outer transaction commits")
+	}
+}
+
+func TestAfterCommit_FailingHookSurfacesErrorWithoutRollback(t *testing.T) {
+	driver := memdriver.New()
+	hookErr := errors.New("publish failed")
+
+	err := pgxtxmanager.SQLTransaction(context.Background(), driver, func(ctx context.Context) error {
+		pgxtxmanager.AfterCommit(ctx, func() error {
+			return hookErr
+		})
+		return nil
+	})
+	if !errors.Is(err, hookErr) {
+		t.Fatalf("err = %v, want to wrap %v", err, hookErr)
+	}
+
+	calls := driver.Calls()
+	for _, call := range calls {
+		if call == "rollback" {
+			t.Fatalf("Calls() = %v, did not expect a rollback after a successful commit", calls)
+		}
+	}
+	if len(calls) == 0 || calls[len(calls)-1] != "commit" {
+		t.Fatalf("Calls() = %v, want the transaction to have committed", calls)
+	}
+}
@@ -0,0 +1,74 @@
+// Package memdriver is an in-memory fake of pgxtxmanager.Driver for tests. It
+// never talks to a real database: it just records Begin/Commit/Rollback and
+// savepoint calls, so repository code written against pgxtxmanager's Driver
+// abstraction can be unit tested without a live connection.
+package memdriver
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Hidayathamir/pgxtxmanager"
+)
+
+// Driver is a pgxtxmanager.Driver fake that records every Begin call and, via
+// the Tx it returns, every Commit/Rollback/Savepoint/ReleaseSavepoint/
+// RollbackToSavepoint call made against it.
+type Driver struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+// New returns a ready-to-use Driver.
+func New() *Driver {
+	return &Driver{}
+}
+
+// Begin implements pgxtxmanager.Driver.
+func (d *Driver) Begin(context.Context, pgxtxmanager.TxOptions) (pgxtxmanager.Tx, error) { //nolint:ireturn
+	d.record("begin")
+	return &tx{driver: d}, nil
+}
+
+// Calls returns the recorded calls in order, e.g. "begin", "savepoint:sp_1",
+// "release_savepoint:sp_1", "commit".
+func (d *Driver) Calls() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.calls...)
+}
+
+func (d *Driver) record(call string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls = append(d.calls, call)
+}
+
+type tx struct {
+	driver *Driver
+}
+
+func (t *tx) Commit(context.Context) error {
+	t.driver.record("commit")
+	return nil
+}
+
+func (t *tx) Rollback(context.Context) error {
+	t.driver.record("rollback")
+	return nil
+}
+
+func (t *tx) Savepoint(_ context.Context, name string) error {
+	t.driver.record("savepoint:" + name)
+	return nil
+}
+
+func (t *tx) ReleaseSavepoint(_ context.Context, name string) error {
+	t.driver.record("release_savepoint:" + name)
+	return nil
+}
+
+func (t *tx) RollbackToSavepoint(_ context.Context, name string) error {
+	t.driver.record("rollback_to_savepoint:" + name)
+	return nil
+}
@@ -0,0 +1,111 @@
+package pgxtxmanager
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies this package to OpenTelemetry.
+const instrumentationName = "github.com/Hidayathamir/pgxtxmanager"
+
+// WithTracerProvider makes SQLTransaction, SQLTransactionTx, and
+// SQLTransactionWithRetry open their spans through tp instead of the
+// globally registered TracerProvider (otel.GetTracerProvider(), which is a
+// no-op until an application wires up an SDK).
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *txConfig) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider makes SQLTransaction, SQLTransactionTx, and
+// SQLTransactionWithRetry record metrics through mp instead of the globally
+// registered MeterProvider (otel.GetMeterProvider(), which is a no-op until
+// an application wires up an SDK).
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *txConfig) {
+		c.meterProvider = mp
+	}
+}
+
+// otelInstruments is the set of spans/metrics a single SQLTransactionTx or
+// SQLTransactionWithRetry call needs. It is resolved from the configured (or
+// default, no-op) providers, so the zero-config path never talks to a real
+// collector.
+type otelInstruments struct {
+	tracer            trace.Tracer
+	beginCount        metric.Int64Counter
+	commitCount       metric.Int64Counter
+	rollbackCount     metric.Int64Counter
+	retryCount        metric.Int64Counter
+	durationHistogram metric.Float64Histogram
+}
+
+// instrumentsKey identifies a (TracerProvider, MeterProvider) pair so
+// resolveOtel only pays the cost of creating instruments once per pair
+// instead of on every transaction, savepoint, and retry attempt.
+type instrumentsKey struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+var instrumentsCache sync.Map // instrumentsKey -> otelInstruments
+
+func resolveOtel(cfg txConfig) otelInstruments {
+	tp := cfg.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	mp := cfg.meterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+
+	key := instrumentsKey{tracerProvider: tp, meterProvider: mp}
+	if cached, ok := instrumentsCache.Load(key); ok {
+		return cached.(otelInstruments) //nolint:forcetypeassert
+	}
+
+	meter := mp.Meter(instrumentationName)
+
+	beginCount, _ := meter.Int64Counter("pgxtx.begin.count")
+	commitCount, _ := meter.Int64Counter("pgxtx.commit.count")
+	rollbackCount, _ := meter.Int64Counter("pgxtx.rollback.count")
+	retryCount, _ := meter.Int64Counter("pgxtx.retry.count")
+	durationHistogram, _ := meter.Float64Histogram("pgxtx.transaction.duration", metric.WithUnit("s"))
+
+	instruments := otelInstruments{
+		tracer:            tp.Tracer(instrumentationName),
+		beginCount:        beginCount,
+		commitCount:       commitCount,
+		rollbackCount:     rollbackCount,
+		retryCount:        retryCount,
+		durationHistogram: durationHistogram,
+	}
+
+	actual, _ := instrumentsCache.LoadOrStore(key, instruments)
+
+	return actual.(otelInstruments) //nolint:forcetypeassert
+}
+
+// isoLevelString renders an IsoLevel as an OTel attribute value.
+func isoLevelString(l IsoLevel) string {
+	switch l {
+	case LevelReadUncommitted:
+		return "read_uncommitted"
+	case LevelReadCommitted:
+		return "read_committed"
+	case LevelRepeatableRead:
+		return "repeatable_read"
+	case LevelSerializable:
+		return "serializable"
+	case LevelDefault:
+		return "default"
+	default:
+		return "default"
+	}
+}
@@ -0,0 +1,117 @@
+// Package pgxdriver adapts github.com/jackc/pgx/v5 to pgxtxmanager.Driver.
+package pgxdriver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Hidayathamir/pgxtxmanager"
+)
+
+// Conn is the subset of *pgx.Conn this driver needs to open transactions.
+type Conn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// Driver adapts a pgx/v5 Conn to pgxtxmanager.Driver.
+type Driver struct {
+	conn Conn
+}
+
+// New wraps conn as a pgxtxmanager.Driver.
+func New(conn Conn) *Driver {
+	return &Driver{conn: conn}
+}
+
+// Begin implements pgxtxmanager.Driver.
+func (d *Driver) Begin(ctx context.Context, txOptions pgxtxmanager.TxOptions) (pgxtxmanager.Tx, error) { //nolint:ireturn
+	tx, err := d.conn.BeginTx(ctx, toPgxTxOptions(txOptions))
+	if err != nil {
+		return nil, fmt.Errorf("pgx.BeginTx: %w", err)
+	}
+	return &Tx{tx: tx}, nil
+}
+
+// toPgxTxOptions translates pgxtxmanager's driver-agnostic TxOptions to
+// pgx's own option type.
+func toPgxTxOptions(txOptions pgxtxmanager.TxOptions) pgx.TxOptions {
+	var isoLevel pgx.TxIsoLevel
+	switch txOptions.Isolation {
+	case pgxtxmanager.LevelReadUncommitted:
+		isoLevel = pgx.ReadUncommitted
+	case pgxtxmanager.LevelReadCommitted:
+		isoLevel = pgx.ReadCommitted
+	case pgxtxmanager.LevelRepeatableRead:
+		isoLevel = pgx.RepeatableRead
+	case pgxtxmanager.LevelSerializable:
+		isoLevel = pgx.Serializable
+	case pgxtxmanager.LevelDefault:
+	}
+
+	accessMode := pgx.ReadWrite
+	if txOptions.ReadOnly {
+		accessMode = pgx.ReadOnly
+	}
+
+	return pgx.TxOptions{IsoLevel: isoLevel, AccessMode: accessMode}
+}
+
+// Tx adapts a pgx.Tx to pgxtxmanager.Tx, implementing savepoints via raw
+// SAVEPOINT/RELEASE SAVEPOINT/ROLLBACK TO SAVEPOINT statements since pgx.Tx
+// itself only exposes anonymous nested transactions through Begin.
+type Tx struct {
+	tx pgx.Tx
+}
+
+// PgxTx returns the underlying pgx.Tx, for callers that need pgx-specific
+// methods such as Query or Exec. It is also accessible via TxFromContext.
+func (t *Tx) PgxTx() pgx.Tx {
+	return t.tx
+}
+
+// Commit implements pgxtxmanager.Tx.
+func (t *Tx) Commit(ctx context.Context) error {
+	return t.tx.Commit(ctx)
+}
+
+// Rollback implements pgxtxmanager.Tx.
+func (t *Tx) Rollback(ctx context.Context) error {
+	return t.tx.Rollback(ctx)
+}
+
+// Savepoint implements pgxtxmanager.Tx.
+func (t *Tx) Savepoint(ctx context.Context, name string) error {
+	_, err := t.tx.Exec(ctx, "SAVEPOINT "+name)
+	return err
+}
+
+// ReleaseSavepoint implements pgxtxmanager.Tx.
+func (t *Tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	_, err := t.tx.Exec(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// RollbackToSavepoint implements pgxtxmanager.Tx.
+func (t *Tx) RollbackToSavepoint(ctx context.Context, name string) error {
+	_, err := t.tx.Exec(ctx, "ROLLBACK TO SAVEPOINT "+name)
+	return err
+}
+
+// TxFromContext retrieves the underlying pgx.Tx stored in ctx by
+// pgxtxmanager, if the active transaction was opened through this driver.
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := pgxtxmanager.GetTxFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	pgxTx, ok := tx.(*Tx)
+	if !ok {
+		return nil, false
+	}
+
+	return pgxTx.PgxTx(), true
+}
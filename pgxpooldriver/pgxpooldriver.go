@@ -0,0 +1,16 @@
+// Package pgxpooldriver adapts github.com/jackc/pgx/v5/pgxpool to
+// pgxtxmanager.Driver.
+package pgxpooldriver
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Hidayathamir/pgxtxmanager/pgxdriver"
+)
+
+// New wraps pool as a pgxtxmanager.Driver. *pgxpool.Pool already satisfies
+// pgxdriver.Conn, so this is a thin rename to keep driver selection explicit
+// at the call site.
+func New(pool *pgxpool.Pool) *pgxdriver.Driver {
+	return pgxdriver.New(pool)
+}
@@ -1,60 +1,281 @@
-// Package pgxtxmanager provides utilities for managing pgx sql transactions.
+// Package pgxtxmanager provides utilities for managing sql transactions
+// across pgx, pgx's connection pool, sqlx, and database/sql, behind a small
+// Driver abstraction.
 package pgxtxmanager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// DBTx defines the interface able to do transactions.
-type DBTx interface {
-	Begin(ctx context.Context) (pgx.Tx, error)
-}
-
 type ctxKey string
 
 // ctxKey represents the context key used for storing the transaction.
 var CtxKey = ctxKey("pgxtxmanager-sql-transaction")
 
-// SQLTransaction executes a function within a PostgreSQL transaction. It begins a new transaction if none exists in the context,
-// otherwise, it uses the existing one. If an error occurs during the execution of the provided function, it rolls back the transaction.
-// If no external transaction exists, it commits the transaction upon successful execution of the function.
-func SQLTransaction(ctx context.Context, dbTx DBTx, fn func(context.Context) error) error {
-	tx, isHasExternalTransaction := ctx.Value(CtxKey).(pgx.Tx)
+// txFrame is what gets stored under CtxKey. It keeps the live Tx alongside
+// the TxOptions it was opened with and its nesting depth, so a nested
+// SQLTransactionTx call can tell whether it is asking for a stricter
+// isolation level than the transaction it would otherwise reuse; depth is
+// also recorded on the OTel span as pgxtx.depth. For a savepoint frame,
+// savepointName identifies the SAVEPOINT it must release or roll back to; it
+// is empty for the outermost frame, which owns the real Tx.
+// hooks is shared by every frame of the same transaction: a hook registered
+// under a given frame is promoted into the parent frame's scope when that
+// frame's savepoint is released, or discarded when it is rolled back, so
+// only hooks registered under work that survives to the outermost frame's
+// own resolution ever run. savepointSeq is likewise shared by every frame of
+// the same transaction, and is the source of savepoint names: depth alone is
+// not unique when two SQLTransaction calls branch concurrently off the same
+// outer ctx (e.g. from an errgroup), since they would otherwise compute the
+// same depth and collide on the same SAVEPOINT name.
+type txFrame struct {
+	tx            Tx
+	txOptions     TxOptions
+	depth         int
+	savepointName string
+	hooks         *hookRegistry
+	savepointSeq  *atomic.Int64
+}
+
+// isOutermost reports whether frame owns the real Tx, as opposed to being a
+// savepoint nested inside it.
+func (frame *txFrame) isOutermost() bool {
+	return frame.savepointName == ""
+}
+
+// Option configures the behavior of SQLTransaction and SQLTransactionTx.
+type Option func(*txConfig)
+
+type txConfig struct {
+	withoutSavepoints bool
+	tracerProvider    trace.TracerProvider
+	meterProvider     metric.MeterProvider
+	attempt           int
+}
+
+// WithoutSavepoints disables savepoint-based nested transactions. A nested
+// SQLTransaction/SQLTransactionTx call then falls back to the legacy
+// behavior of simply running fn under the outer transaction, so an inner
+// failure rolls back the whole outer transaction instead of just the
+// savepoint. Use it for drivers or workloads that don't want the overhead of
+// a SAVEPOINT per nested call.
+func WithoutSavepoints() Option {
+	return func(c *txConfig) {
+		c.withoutSavepoints = true
+	}
+}
+
+func resolveOptions(opts []Option) txConfig {
+	cfg := txConfig{attempt: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// withAttempt records the retry attempt number (1 for the first try) on the
+// span SQLTransactionWithRetry opens for each attempt. It is only ever set
+// internally by SQLTransactionWithRetry.
+func withAttempt(attempt int) Option {
+	return func(c *txConfig) {
+		c.attempt = attempt
+	}
+}
+
+// SQLTransaction executes a function within a SQL transaction. It begins a new transaction if none exists in the context,
+// otherwise, it opens a SAVEPOINT nested inside it so that an inner failure can be rolled back without poisoning the outer
+// transaction. If an error occurs during the execution of the provided function, it rolls back to that point. If no external
+// transaction exists, it commits the transaction upon successful execution of the function.
+func SQLTransaction(ctx context.Context, driver Driver, fn func(context.Context) error, opts ...Option) error {
+	return SQLTransactionTx(ctx, driver, TxOptions{}, fn, opts...)
+}
+
+// SQLTransactionTx behaves like SQLTransaction but lets the caller pick the
+// TxOptions (isolation level and read-only access) for the transaction it
+// opens.
+//
+// If a transaction already exists in the context, SQLTransactionTx opens a
+// SAVEPOINT nested inside it instead of reusing it outright, unless
+// WithoutSavepoints is passed. If txOptions asks for a stricter isolation
+// level than that outer transaction was opened with, it returns an error
+// instead of silently running fn under the weaker outer transaction.
+//
+// If fn panics, the transaction (or savepoint) is rolled back and the panic
+// is re-raised after cleanup, so a panicking fn never leaks a live
+// transaction. A rollback error encountered on the error path is joined with
+// the triggering error via errors.Join instead of being merely logged. If
+// the transaction commits but a registered AfterCommit hook then fails, the
+// returned error is a *CommitHookError so callers (in particular
+// SQLTransactionWithRetry) can tell a post-commit hook failure apart from a
+// failure that happened before the transaction was durable.
+//
+// SQLTransactionTx also opens an OTel span (pgxtx.transaction for the
+// outermost frame, pgxtx.savepoint for a nested one) recording the isolation
+// level, access mode, retry attempt number, nesting depth, duration, and
+// outcome (committed/rolled_back/panicked, or open_failed/commit_failed/
+// commit_hook_failed), and records begin/commit/
+// rollback counts and transaction duration through an injectable meter. Both
+// default to OTel's globally registered, no-op providers; pass
+// WithTracerProvider and/or WithMeterProvider to wire up a real SDK.
+func SQLTransactionTx(ctx context.Context, driver Driver, txOptions TxOptions, fn func(context.Context) error, opts ...Option) (err error) {
+	cfg := resolveOptions(opts)
+
+	outerFrame, isHasExternalTransaction := ctx.Value(CtxKey).(*txFrame)
+
+	if isHasExternalTransaction && isoLevelRank[txOptions.Isolation] > isoLevelRank[outerFrame.txOptions.Isolation] {
+		return fmt.Errorf("pgxtxmanager: requested isolation level %d is stricter than outer transaction isolation level %d", txOptions.Isolation, outerFrame.txOptions.Isolation)
+	}
+
+	if isHasExternalTransaction && cfg.withoutSavepoints {
+		return fn(context.WithValue(ctx, CtxKey, outerFrame))
+	}
+
+	instruments := resolveOtel(cfg)
+
+	spanName := "pgxtx.transaction"
+	depth := 0
+	if isHasExternalTransaction {
+		spanName = "pgxtx.savepoint"
+		depth = outerFrame.depth + 1
+	}
+
+	ctx, span := instruments.tracer.Start(ctx, spanName, trace.WithAttributes(
+		attribute.String("pgxtx.isolation_level", isoLevelString(txOptions.Isolation)),
+		attribute.Bool("pgxtx.read_only", txOptions.ReadOnly),
+		attribute.Int("pgxtx.attempt", cfg.attempt),
+		attribute.Int("pgxtx.depth", depth),
+	))
+	start := time.Now()
+
+	var frame *txFrame
+
+	endFailedSpan := func(errOpen error) {
+		span.RecordError(errOpen)
+		span.SetAttributes(attribute.String("pgxtx.outcome", "open_failed"))
+		instruments.durationHistogram.Record(ctx, time.Since(start).Seconds())
+		span.End()
+	}
 
 	if !isHasExternalTransaction {
-		var err error
-		tx, err = dbTx.Begin(ctx)
-		if err != nil {
-			return fmt.Errorf("DBTx.Begin: %w", err)
+		tx, errBegin := driver.Begin(ctx, txOptions)
+		if errBegin != nil {
+			endFailedSpan(errBegin)
+			return fmt.Errorf("Driver.Begin: %w", errBegin)
+		}
+		frame = &txFrame{tx: tx, txOptions: txOptions, hooks: &hookRegistry{}, savepointSeq: new(atomic.Int64)}
+		instruments.beginCount.Add(ctx, 1)
+	} else {
+		savepointName := fmt.Sprintf("sp_%d", outerFrame.savepointSeq.Add(1))
+		if errSavepoint := outerFrame.tx.Savepoint(ctx, savepointName); errSavepoint != nil {
+			endFailedSpan(errSavepoint)
+			return fmt.Errorf("Tx.Savepoint: %w", errSavepoint)
 		}
-		ctx = context.WithValue(ctx, CtxKey, tx)
+		frame = &txFrame{tx: outerFrame.tx, txOptions: outerFrame.txOptions, depth: depth, savepointName: savepointName, hooks: outerFrame.hooks, savepointSeq: outerFrame.savepointSeq}
 	}
 
-	err := fn(ctx)
+	ctx = context.WithValue(ctx, CtxKey, frame)
+
+	defer func() {
+		outcome := "committed"
+
+		if r := recover(); r != nil {
+			if errRollback := rollbackFrame(ctx, frame); errRollback != nil {
+				slog.Warn("pgxtxmanager: rollback after panic", "error", errRollback)
+			}
+			if frame.isOutermost() {
+				frame.hooks.runAfterRollback()
+				instruments.rollbackCount.Add(ctx, 1)
+			} else {
+				frame.hooks.discard(frame)
+			}
+			span.SetAttributes(attribute.String("pgxtx.outcome", "panicked"))
+			instruments.durationHistogram.Record(ctx, time.Since(start).Seconds())
+			span.End()
+			panic(r)
+		}
 
-	if !isHasExternalTransaction {
 		if err != nil {
-			errRollback := tx.Rollback(ctx)
-			if errRollback != nil {
-				slog.Warn("pgx.Tx.Rollback: %v", errRollback)
+			if errRollback := rollbackFrame(ctx, frame); errRollback != nil {
+				err = errors.Join(err, fmt.Errorf("rollback: %w", errRollback))
+			}
+			if frame.isOutermost() {
+				frame.hooks.runAfterRollback()
+				instruments.rollbackCount.Add(ctx, 1)
+			} else {
+				frame.hooks.discard(frame)
+			}
+			span.RecordError(err)
+			span.SetAttributes(attribute.String("pgxtx.outcome", "rolled_back"))
+			instruments.durationHistogram.Record(ctx, time.Since(start).Seconds())
+			span.End()
+			return
+		}
+
+		if errCommit := commitFrame(ctx, frame); errCommit != nil {
+			err = fmt.Errorf("commit: %w", errCommit)
+			if !frame.isOutermost() {
+				frame.hooks.discard(frame)
 			}
-			return err
+			span.RecordError(err)
+			span.SetAttributes(attribute.String("pgxtx.outcome", "commit_failed"))
+			instruments.durationHistogram.Record(ctx, time.Since(start).Seconds())
+			span.End()
+			return
 		}
-		errCommit := tx.Commit(ctx)
-		if errCommit != nil {
-			return fmt.Errorf("pgx.Tx.Commit: %w", errCommit)
+
+		if frame.isOutermost() {
+			instruments.commitCount.Add(ctx, 1)
+			if hookErr := frame.hooks.runAfterCommit(); hookErr != nil {
+				err = &CommitHookError{err: hookErr}
+				span.RecordError(err)
+				outcome = "commit_hook_failed"
+			}
+		} else {
+			frame.hooks.promote(frame, outerFrame)
 		}
-	}
+
+		span.SetAttributes(attribute.String("pgxtx.outcome", outcome))
+		instruments.durationHistogram.Record(ctx, time.Since(start).Seconds())
+		span.End()
+	}()
+
+	err = fn(ctx)
 
 	return err
 }
 
-// GetTxFromContext retrieves the PostgreSQL transaction from the context, if available.
-func GetTxFromContext(ctx context.Context) (pgx.Tx, bool) { //nolint:ireturn
-	tx, ok := ctx.Value(CtxKey).(pgx.Tx)
-	return tx, ok
+// commitFrame commits the outermost frame's Tx, or releases the savepoint
+// for a nested frame.
+func commitFrame(ctx context.Context, frame *txFrame) error {
+	if frame.savepointName != "" {
+		return frame.tx.ReleaseSavepoint(ctx, frame.savepointName)
+	}
+	return frame.tx.Commit(ctx)
+}
+
+// rollbackFrame rolls back the outermost frame's Tx, or rolls back to the
+// savepoint for a nested frame.
+func rollbackFrame(ctx context.Context, frame *txFrame) error {
+	if frame.savepointName != "" {
+		return frame.tx.RollbackToSavepoint(ctx, frame.savepointName)
+	}
+	return frame.tx.Rollback(ctx)
+}
+
+// GetTxFromContext retrieves the transaction from the context, if available.
+func GetTxFromContext(ctx context.Context) (Tx, bool) { //nolint:ireturn
+	frame, ok := ctx.Value(CtxKey).(*txFrame)
+	if !ok {
+		return nil, false
+	}
+	return frame.tx, true
 }
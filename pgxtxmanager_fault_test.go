@@ -0,0 +1,87 @@
+package pgxtxmanager_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Hidayathamir/pgxtxmanager"
+)
+
+// faultyDriver is a pgxtxmanager.Driver fake that can be told to fail
+// Rollback, for exercising SQLTransactionTx's error paths that memdriver
+// (which never fails) cannot reach.
+type faultyDriver struct {
+	rollbackErr error
+
+	rollbackCalled         bool
+	rollbackToSavepointArg string
+}
+
+func (d *faultyDriver) Begin(context.Context, pgxtxmanager.TxOptions) (pgxtxmanager.Tx, error) { //nolint:ireturn
+	return &faultyTx{driver: d}, nil
+}
+
+type faultyTx struct {
+	driver *faultyDriver
+}
+
+func (t *faultyTx) Commit(context.Context) error { return nil }
+
+func (t *faultyTx) Rollback(context.Context) error {
+	t.driver.rollbackCalled = true
+	return t.driver.rollbackErr
+}
+
+func (t *faultyTx) Savepoint(context.Context, string) error { return nil }
+
+func (t *faultyTx) ReleaseSavepoint(context.Context, string) error { return nil }
+
+func (t *faultyTx) RollbackToSavepoint(_ context.Context, name string) error {
+	t.driver.rollbackCalled = true
+	t.driver.rollbackToSavepointArg = name
+	return t.driver.rollbackErr
+}
+
+func TestSQLTransactionTx_PanicRollsBackAndRepanics(t *testing.T) {
+	driver := &faultyDriver{}
+
+	panicValue := "boom"
+
+	func() {
+		defer func() {
+			r := recover()
+			if r != panicValue {
+				t.Fatalf("recover() = %v, want %v", r, panicValue)
+			}
+		}()
+
+		_ = pgxtxmanager.SQLTransactionTx(context.Background(), driver, pgxtxmanager.TxOptions{}, func(context.Context) error {
+			panic(panicValue)
+		})
+	}()
+
+	if !driver.rollbackCalled {
+		t.Fatal("expected Rollback to be called after fn panicked")
+	}
+}
+
+func TestSQLTransactionTx_ErrorAndRollbackErrorAreJoined(t *testing.T) {
+	fnErr := errors.New("fn failed")
+	rollbackErr := errors.New("rollback failed")
+	driver := &faultyDriver{rollbackErr: rollbackErr}
+
+	err := pgxtxmanager.SQLTransactionTx(context.Background(), driver, pgxtxmanager.TxOptions{}, func(context.Context) error {
+		return fnErr
+	})
+
+	if !driver.rollbackCalled {
+		t.Fatal("expected Rollback to be called")
+	}
+	if !errors.Is(err, fnErr) {
+		t.Fatalf("err = %v, want to wrap %v", err, fnErr)
+	}
+	if !errors.Is(err, rollbackErr) {
+		t.Fatalf("err = %v, want to wrap %v", err, rollbackErr)
+	}
+}
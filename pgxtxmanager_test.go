@@ -0,0 +1,69 @@
+package pgxtxmanager_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Hidayathamir/pgxtxmanager"
+	"github.com/Hidayathamir/pgxtxmanager/memdriver"
+)
+
+func TestSQLTransaction_NestedCommit(t *testing.T) {
+	driver := memdriver.New()
+
+	err := pgxtxmanager.SQLTransaction(context.Background(), driver, func(ctx context.Context) error {
+		return pgxtxmanager.SQLTransaction(ctx, driver, func(context.Context) error {
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("SQLTransaction: %v", err)
+	}
+
+	want := []string{"begin", "savepoint:sp_1", "release_savepoint:sp_1", "commit"}
+	if got := driver.Calls(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Calls() = %v, want %v", got, want)
+	}
+}
+
+func TestSQLTransaction_NestedRollback(t *testing.T) {
+	driver := memdriver.New()
+	innerErr := errors.New("inner failure")
+
+	err := pgxtxmanager.SQLTransaction(context.Background(), driver, func(ctx context.Context) error {
+		return pgxtxmanager.SQLTransaction(ctx, driver, func(context.Context) error {
+			return innerErr
+		})
+	})
+	if !errors.Is(err, innerErr) {
+		t.Fatalf("err = %v, want to wrap %v", err, innerErr)
+	}
+
+	want := []string{"begin", "savepoint:sp_1", "rollback_to_savepoint:sp_1", "rollback"}
+	if got := driver.Calls(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Calls() = %v, want %v", got, want)
+	}
+}
+
+func TestSQLTransactionTx_NestedStricterIsolationRejected(t *testing.T) {
+	driver := memdriver.New()
+
+	outer := pgxtxmanager.TxOptions{Isolation: pgxtxmanager.LevelReadCommitted}
+	inner := pgxtxmanager.TxOptions{Isolation: pgxtxmanager.LevelSerializable}
+
+	err := pgxtxmanager.SQLTransactionTx(context.Background(), driver, outer, func(ctx context.Context) error {
+		return pgxtxmanager.SQLTransactionTx(ctx, driver, inner, func(context.Context) error {
+			return nil
+		})
+	})
+	if err == nil {
+		t.Fatal("expected an error requesting a stricter isolation level than the outer transaction")
+	}
+
+	want := []string{"begin", "rollback"}
+	if got := driver.Calls(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Calls() = %v, want %v (no savepoint should have been opened, and the outer transaction rolls back since fn returned an error)", got, want)
+	}
+}
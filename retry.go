@@ -0,0 +1,163 @@
+package pgxtxmanager
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Serialization failure and deadlock SQLSTATEs, per
+// https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// BackoffPolicy computes how long to wait before the next attempt, given the
+// number of attempts already made (starting at 1 after the first failure).
+type BackoffPolicy func(attempt int) time.Duration
+
+// ConstantBackoff returns a BackoffPolicy that always waits d between attempts.
+func ConstantBackoff(d time.Duration) BackoffPolicy {
+	return func(attempt int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a BackoffPolicy that doubles base for every
+// failed attempt, caps the result at max, and applies full jitter so the
+// returned duration is a random value in [0, cap).
+func ExponentialBackoff(base, max time.Duration) BackoffPolicy { //nolint:predeclared
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		if d <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(d))) //nolint:gosec
+	}
+}
+
+// RetryOptions configures SQLTransactionWithRetry.
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of times fn is run, including the
+	// first attempt. Values less than 1 are treated as 1 (no retries).
+	MaxAttempts int
+	// Backoff is consulted between attempts. A nil Backoff means no delay.
+	Backoff BackoffPolicy
+	// IsRetryable reports whether err should trigger another attempt. If
+	// nil, IsSerializationOrDeadlockError is used.
+	IsRetryable func(error) bool
+	// TxOptions is passed through to the underlying SQLTransactionTx call.
+	TxOptions TxOptions
+}
+
+// IsSerializationOrDeadlockError reports whether err is a PostgreSQL
+// serialization_failure (40001) or deadlock_detected (40P01) error, the two
+// SQLSTATEs it is generally safe to blindly retry.
+func IsSerializationOrDeadlockError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+}
+
+// SQLTransactionWithRetry behaves like SQLTransactionTx, but if fn (or the
+// commit) fails with a retryable error, it rolls back and re-runs fn on a
+// fresh transaction, up to retryOptions.MaxAttempts times in total.
+//
+// The retry loop only engages at the outermost frame: if ctx already carries
+// a transaction, SQLTransactionWithRetry just delegates to SQLTransactionTx,
+// since retrying a nested savepoint in isolation would leave the outer
+// transaction in an inconsistent state. Each attempt re-derives ctx from the
+// original ctx passed in, so a tx stored under CtxKey by a failed attempt is
+// never reused by the next one.
+//
+// A *CommitHookError is never retried, regardless of IsRetryable: it means
+// the transaction already committed and only an AfterCommit hook failed
+// afterward, so retrying would re-run fn from scratch against a brand new
+// transaction despite the original work already being durable.
+func SQLTransactionWithRetry(ctx context.Context, driver Driver, retryOptions RetryOptions, fn func(context.Context) error, opts ...Option) error {
+	if _, isHasExternalTransaction := ctx.Value(CtxKey).(*txFrame); isHasExternalTransaction {
+		return SQLTransactionTx(ctx, driver, retryOptions.TxOptions, fn, opts...)
+	}
+
+	maxAttempts := retryOptions.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	isRetryable := retryOptions.IsRetryable
+	if isRetryable == nil {
+		isRetryable = IsSerializationOrDeadlockError
+	}
+
+	instruments := resolveOtel(resolveOptions(opts))
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = SQLTransactionTx(ctx, driver, retryOptions.TxOptions, fn, append(opts, withAttempt(attempt))...)
+		if err == nil {
+			return nil
+		}
+
+		var hookErr *CommitHookError
+		if errors.As(err, &hookErr) {
+			return err
+		}
+
+		if attempt == maxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		instruments.retryCount.Add(ctx, 1, metric.WithAttributes(attribute.String("pgxtx.sqlstate", sqlState(err))))
+
+		if retryOptions.Backoff == nil {
+			continue
+		}
+
+		if waitErr := sleep(ctx, retryOptions.Backoff(attempt)); waitErr != nil {
+			return waitErr
+		}
+	}
+
+	return err
+}
+
+// sqlState returns the PostgreSQL SQLSTATE carried by err, or "unknown" if
+// err does not wrap a *pgconn.PgError.
+func sqlState(err error) string {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return "unknown"
+	}
+	return pgErr.Code
+}
+
+// sleep waits for d, returning ctx.Err() early if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
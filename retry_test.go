@@ -0,0 +1,130 @@
+package pgxtxmanager_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Hidayathamir/pgxtxmanager"
+	"github.com/Hidayathamir/pgxtxmanager/memdriver"
+)
+
+func beginCount(calls []string) int {
+	n := 0
+	for _, call := range calls {
+		if call == "begin" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSQLTransactionWithRetry(t *testing.T) {
+	serializationErr := &pgconn.PgError{Code: "40001"}
+	deadlockErr := &pgconn.PgError{Code: "40P01"}
+	nonRetryableErr := errors.New("not retryable")
+
+	tests := []struct {
+		name            string
+		failWith        error
+		failAttempts    int
+		maxAttempts     int
+		wantBeginCount  int
+		wantErrIsFailer bool
+	}{
+		{
+			name:           "serialization failure retries until success",
+			failWith:       serializationErr,
+			failAttempts:   2,
+			maxAttempts:    5,
+			wantBeginCount: 3,
+		},
+		{
+			name:           "deadlock retries until success",
+			failWith:       deadlockErr,
+			failAttempts:   1,
+			maxAttempts:    5,
+			wantBeginCount: 2,
+		},
+		{
+			name:            "non-retryable error returns after a single attempt",
+			failWith:        nonRetryableErr,
+			failAttempts:    5,
+			maxAttempts:     5,
+			wantBeginCount:  1,
+			wantErrIsFailer: true,
+		},
+		{
+			name:            "MaxAttempts caps retries on a persistently retryable error",
+			failWith:        serializationErr,
+			failAttempts:    100,
+			maxAttempts:     3,
+			wantBeginCount:  3,
+			wantErrIsFailer: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := memdriver.New()
+			remaining := tt.failAttempts
+
+			err := pgxtxmanager.SQLTransactionWithRetry(context.Background(), driver, pgxtxmanager.RetryOptions{
+				MaxAttempts: tt.maxAttempts,
+			}, func(context.Context) error {
+				if remaining > 0 {
+					remaining--
+					return tt.failWith
+				}
+				return nil
+			})
+
+			if got := beginCount(driver.Calls()); got != tt.wantBeginCount {
+				t.Fatalf("Driver.Begin called %d times, want %d (calls: %v)", got, tt.wantBeginCount, driver.Calls())
+			}
+
+			if tt.wantErrIsFailer {
+				if !errors.Is(err, tt.failWith) {
+					t.Fatalf("err = %v, want to wrap %v", err, tt.failWith)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SQLTransactionWithRetry: %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLTransactionWithRetry_CommitHookErrorIsNeverRetried(t *testing.T) {
+	driver := memdriver.New()
+	hookErr := &pgconn.PgError{Code: "40001"}
+
+	fnCalls := 0
+	err := pgxtxmanager.SQLTransactionWithRetry(context.Background(), driver, pgxtxmanager.RetryOptions{
+		MaxAttempts: 5,
+	}, func(ctx context.Context) error {
+		fnCalls++
+		pgxtxmanager.AfterCommit(ctx, func() error {
+			return hookErr
+		})
+		return nil
+	})
+
+	var commitHookErr *pgxtxmanager.CommitHookError
+	if !errors.As(err, &commitHookErr) {
+		t.Fatalf("err = %v, want a *pgxtxmanager.CommitHookError even though its cause looks retryable", err)
+	}
+	if !errors.Is(err, hookErr) {
+		t.Fatalf("err = %v, want to wrap %v", err, hookErr)
+	}
+	if fnCalls != 1 {
+		t.Fatalf("fn called %d times, want exactly 1: a committed transaction with a failing hook must never be retried", fnCalls)
+	}
+	if got := beginCount(driver.Calls()); got != 1 {
+		t.Fatalf("Driver.Begin called %d times, want 1 (calls: %v)", got, driver.Calls())
+	}
+}
@@ -0,0 +1,112 @@
+// Package sqlxdriver adapts github.com/jmoiron/sqlx to pgxtxmanager.Driver.
+package sqlxdriver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Hidayathamir/pgxtxmanager"
+)
+
+// DB is the subset of *sqlx.DB this driver needs to open transactions.
+type DB interface {
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+}
+
+// Driver adapts a sqlx DB to pgxtxmanager.Driver.
+type Driver struct {
+	db DB
+}
+
+// New wraps db as a pgxtxmanager.Driver.
+func New(db DB) *Driver {
+	return &Driver{db: db}
+}
+
+// Begin implements pgxtxmanager.Driver.
+func (d *Driver) Begin(ctx context.Context, txOptions pgxtxmanager.TxOptions) (pgxtxmanager.Tx, error) { //nolint:ireturn
+	tx, err := d.db.BeginTxx(ctx, toSQLTxOptions(txOptions))
+	if err != nil {
+		return nil, fmt.Errorf("sqlx.DB.BeginTxx: %w", err)
+	}
+	return &Tx{tx: tx}, nil
+}
+
+// toSQLTxOptions translates pgxtxmanager's driver-agnostic TxOptions to
+// database/sql's own option type, which sqlx reuses as-is.
+func toSQLTxOptions(txOptions pgxtxmanager.TxOptions) *sql.TxOptions {
+	var isoLevel sql.IsolationLevel
+	switch txOptions.Isolation {
+	case pgxtxmanager.LevelReadUncommitted:
+		isoLevel = sql.LevelReadUncommitted
+	case pgxtxmanager.LevelReadCommitted:
+		isoLevel = sql.LevelReadCommitted
+	case pgxtxmanager.LevelRepeatableRead:
+		isoLevel = sql.LevelRepeatableRead
+	case pgxtxmanager.LevelSerializable:
+		isoLevel = sql.LevelSerializable
+	case pgxtxmanager.LevelDefault:
+	}
+
+	return &sql.TxOptions{Isolation: isoLevel, ReadOnly: txOptions.ReadOnly}
+}
+
+// Tx adapts a *sqlx.Tx to pgxtxmanager.Tx. Savepoints are implemented via raw
+// SAVEPOINT/RELEASE SAVEPOINT/ROLLBACK TO SAVEPOINT statements, since
+// database/sql has no native concept of a nested transaction.
+type Tx struct {
+	tx *sqlx.Tx
+}
+
+// SqlxTx returns the underlying *sqlx.Tx, for callers that need sqlx-specific
+// methods such as Select or Get. It is also accessible via TxFromContext.
+func (t *Tx) SqlxTx() *sqlx.Tx {
+	return t.tx
+}
+
+// Commit implements pgxtxmanager.Tx.
+func (t *Tx) Commit(context.Context) error {
+	return t.tx.Commit()
+}
+
+// Rollback implements pgxtxmanager.Tx.
+func (t *Tx) Rollback(context.Context) error {
+	return t.tx.Rollback()
+}
+
+// Savepoint implements pgxtxmanager.Tx.
+func (t *Tx) Savepoint(ctx context.Context, name string) error {
+	_, err := t.tx.ExecContext(ctx, "SAVEPOINT "+name)
+	return err
+}
+
+// ReleaseSavepoint implements pgxtxmanager.Tx.
+func (t *Tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	_, err := t.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// RollbackToSavepoint implements pgxtxmanager.Tx.
+func (t *Tx) RollbackToSavepoint(ctx context.Context, name string) error {
+	_, err := t.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+	return err
+}
+
+// TxFromContext retrieves the underlying *sqlx.Tx stored in ctx by
+// pgxtxmanager, if the active transaction was opened through this driver.
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	tx, ok := pgxtxmanager.GetTxFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	sqlxTx, ok := tx.(*Tx)
+	if !ok {
+		return nil, false
+	}
+
+	return sqlxTx.SqlxTx(), true
+}
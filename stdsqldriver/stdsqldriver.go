@@ -0,0 +1,110 @@
+// Package stdsqldriver adapts database/sql to pgxtxmanager.Driver.
+package stdsqldriver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Hidayathamir/pgxtxmanager"
+)
+
+// DB is the subset of *sql.DB this driver needs to open transactions.
+type DB interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// Driver adapts a database/sql DB to pgxtxmanager.Driver.
+type Driver struct {
+	db DB
+}
+
+// New wraps db as a pgxtxmanager.Driver.
+func New(db DB) *Driver {
+	return &Driver{db: db}
+}
+
+// Begin implements pgxtxmanager.Driver.
+func (d *Driver) Begin(ctx context.Context, txOptions pgxtxmanager.TxOptions) (pgxtxmanager.Tx, error) { //nolint:ireturn
+	tx, err := d.db.BeginTx(ctx, toSQLTxOptions(txOptions))
+	if err != nil {
+		return nil, fmt.Errorf("sql.DB.BeginTx: %w", err)
+	}
+	return &Tx{tx: tx}, nil
+}
+
+// toSQLTxOptions translates pgxtxmanager's driver-agnostic TxOptions to
+// database/sql's own option type.
+func toSQLTxOptions(txOptions pgxtxmanager.TxOptions) *sql.TxOptions {
+	var isoLevel sql.IsolationLevel
+	switch txOptions.Isolation {
+	case pgxtxmanager.LevelReadUncommitted:
+		isoLevel = sql.LevelReadUncommitted
+	case pgxtxmanager.LevelReadCommitted:
+		isoLevel = sql.LevelReadCommitted
+	case pgxtxmanager.LevelRepeatableRead:
+		isoLevel = sql.LevelRepeatableRead
+	case pgxtxmanager.LevelSerializable:
+		isoLevel = sql.LevelSerializable
+	case pgxtxmanager.LevelDefault:
+	}
+
+	return &sql.TxOptions{Isolation: isoLevel, ReadOnly: txOptions.ReadOnly}
+}
+
+// Tx adapts a *sql.Tx to pgxtxmanager.Tx. Savepoints are implemented via raw
+// SAVEPOINT/RELEASE SAVEPOINT/ROLLBACK TO SAVEPOINT statements, since
+// database/sql has no native concept of a nested transaction.
+type Tx struct {
+	tx *sql.Tx
+}
+
+// SQLTx returns the underlying *sql.Tx, for callers that need to run queries
+// against it. It is also accessible via TxFromContext.
+func (t *Tx) SQLTx() *sql.Tx {
+	return t.tx
+}
+
+// Commit implements pgxtxmanager.Tx.
+func (t *Tx) Commit(context.Context) error {
+	return t.tx.Commit()
+}
+
+// Rollback implements pgxtxmanager.Tx.
+func (t *Tx) Rollback(context.Context) error {
+	return t.tx.Rollback()
+}
+
+// Savepoint implements pgxtxmanager.Tx.
+func (t *Tx) Savepoint(ctx context.Context, name string) error {
+	_, err := t.tx.ExecContext(ctx, "SAVEPOINT "+name)
+	return err
+}
+
+// ReleaseSavepoint implements pgxtxmanager.Tx.
+func (t *Tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	_, err := t.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// RollbackToSavepoint implements pgxtxmanager.Tx.
+func (t *Tx) RollbackToSavepoint(ctx context.Context, name string) error {
+	_, err := t.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+	return err
+}
+
+// TxFromContext retrieves the underlying *sql.Tx stored in ctx by
+// pgxtxmanager, if the active transaction was opened through this driver.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := pgxtxmanager.GetTxFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	sqlTx, ok := tx.(*Tx)
+	if !ok {
+		return nil, false
+	}
+
+	return sqlTx.SQLTx(), true
+}